@@ -0,0 +1,131 @@
+// Copyright 2016 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"code.gitea.io/gitea/modules/private"
+
+	"github.com/urfave/cli"
+)
+
+// CmdHook is the top level command for managing git hooks
+var CmdHook = cli.Command{
+	Name:        "hook",
+	Usage:       "Delegate commands to corresponding Gitea hooks",
+	Description: "This should only be called by Git",
+	Subcommands: []cli.Command{
+		subcmdHookPreReceive,
+		subcmdHookPostReceive,
+	},
+}
+
+var subcmdHookPreReceive = cli.Command{
+	Name:        "pre-receive",
+	Usage:       "Delegate pre-receive Git hook",
+	Description: "This command should only be called by Git",
+	Action:      runHookPreReceive,
+}
+
+var subcmdHookPostReceive = cli.Command{
+	Name:        "post-receive",
+	Usage:       "Delegate post-receive Git hook",
+	Description: "This command should only be called by Git",
+	Action:      runHookPostReceive,
+}
+
+const (
+	envPusherID   = "GITEA_PUSHER_ID"
+	envPusherName = "GITEA_PUSHER_NAME"
+	envRepoOwner  = "GITEA_REPO_USER_NAME"
+	envRepoName   = "GITEA_REPO_NAME"
+)
+
+// parsePushOptions reads the `key=value` pairs passed via `git push -o` from
+// the GIT_PUSH_OPTION_COUNT/GIT_PUSH_OPTION_N environment variables git sets
+// before invoking the pre-receive/post-receive hooks, so they can be
+// forwarded to HookOptions.PushOptions.
+func parsePushOptions() map[string]string {
+	options := map[string]string{}
+	count, _ := strconv.Atoi(os.Getenv(private.GitPushOptionCount))
+	for i := 0; i < count; i++ {
+		opt := os.Getenv(fmt.Sprintf("GIT_PUSH_OPTION_%d", i))
+		if opt == "" {
+			continue
+		}
+		key, value := opt, "true"
+		if idx := strings.IndexByte(opt, '='); idx >= 0 {
+			key, value = opt[:idx], opt[idx+1:]
+		}
+		options[key] = value
+	}
+	return options
+}
+
+// hookOptionsFromEnvironment builds a HookOptions populated from the
+// environment Git sets for the running hook, excluding the ref updates
+// themselves, which are read from stdin by readHookInput.
+func hookOptionsFromEnvironment() private.HookOptions {
+	userID, _ := strconv.ParseInt(os.Getenv(envPusherID), 10, 64)
+	return private.HookOptions{
+		UserID:                          userID,
+		UserName:                        os.Getenv(envPusherName),
+		GitObjectDirectory:              os.Getenv(private.GitObjectDirectory),
+		GitQuarantinePath:               os.Getenv(private.GitQuarantinePath),
+		GitAlternativeObjectDirectories: os.Getenv(private.GitAlternativeObjectDirectories),
+		PushOptions:                     parsePushOptions(),
+	}
+}
+
+// readHookInput reads the "<old-value> <new-value> <ref-name>" lines Git
+// feeds a pre-receive/post-receive hook on stdin.
+func readHookInput() (oldCommitIDs, newCommitIDs, refFullNames []string, err error) {
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 3 {
+			continue
+		}
+		oldCommitIDs = append(oldCommitIDs, fields[0])
+		newCommitIDs = append(newCommitIDs, fields[1])
+		refFullNames = append(refFullNames, fields[2])
+	}
+	return oldCommitIDs, newCommitIDs, refFullNames, scanner.Err()
+}
+
+func runHookPreReceive(c *cli.Context) error {
+	opts := hookOptionsFromEnvironment()
+
+	oldCommitIDs, newCommitIDs, refFullNames, err := readHookInput()
+	if err != nil {
+		return err
+	}
+	opts.OldCommitIDs = oldCommitIDs
+	opts.NewCommitIDs = newCommitIDs
+	opts.RefFullNames = refFullNames
+
+	_, err = private.Hook("pre-receive", os.Getenv(envRepoOwner), os.Getenv(envRepoName), opts)
+	return err
+}
+
+func runHookPostReceive(c *cli.Context) error {
+	opts := hookOptionsFromEnvironment()
+
+	oldCommitIDs, newCommitIDs, refFullNames, err := readHookInput()
+	if err != nil {
+		return err
+	}
+	opts.OldCommitIDs = oldCommitIDs
+	opts.NewCommitIDs = newCommitIDs
+	opts.RefFullNames = refFullNames
+
+	_, err = private.Hook("post-receive", os.Getenv(envRepoOwner), os.Getenv(envRepoName), opts)
+	return err
+}
@@ -0,0 +1,35 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"os"
+	"testing"
+
+	"code.gitea.io/gitea/modules/private"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParsePushOptions(t *testing.T) {
+	defer func() {
+		os.Unsetenv(private.GitPushOptionCount)
+		os.Unsetenv("GIT_PUSH_OPTION_0")
+		os.Unsetenv("GIT_PUSH_OPTION_1")
+	}()
+
+	os.Setenv(private.GitPushOptionCount, "2")
+	os.Setenv("GIT_PUSH_OPTION_0", "ci.skip=true")
+	os.Setenv("GIT_PUSH_OPTION_1", "topic")
+
+	options := parsePushOptions()
+	assert.Equal(t, "true", options["ci.skip"])
+	assert.Equal(t, "true", options["topic"])
+}
+
+func TestParsePushOptionsNoneSet(t *testing.T) {
+	os.Unsetenv(private.GitPushOptionCount)
+	assert.Empty(t, parsePushOptions())
+}
@@ -0,0 +1,120 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"strings"
+
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"github.com/gobwas/glob"
+)
+
+// ProtectedBranch struct
+type ProtectedBranch struct {
+	ID                        int64  `xorm:"pk autoincr"`
+	RepoID                    int64  `xorm:"UNIQUE(s)"`
+	BranchName                string `xorm:"UNIQUE(s)"`
+	CanPush                   bool   `xorm:"NOT NULL DEFAULT false"`
+	EnableWhitelist           bool
+	WhitelistUserIDs          []int64 `xorm:"JSON TEXT"`
+	WhitelistTeamIDs          []int64 `xorm:"JSON TEXT"`
+	WhitelistDeployKeys       bool    `xorm:"NOT NULL DEFAULT false"`
+	RequireSignedCommits      bool    `xorm:"NOT NULL DEFAULT false"`
+	ProtectedFilePatterns     string  `xorm:"TEXT"`
+
+	// RequiredPushOptions and ForbiddenPushOptions are the allow/deny list
+	// of `git push -o` option keys enforced by checkPushOptionPolicy.
+	RequiredPushOptions  []string `xorm:"JSON TEXT"`
+	ForbiddenPushOptions []string `xorm:"JSON TEXT"`
+
+	// CommitMessageRegex, ForbiddenCommitMessagePatterns,
+	// MaxCommitSubjectLength and RequiredCommitTrailers configure the
+	// commit-message policy enforced by checkCommitAgainstPolicy.
+	CommitMessageRegex             string   `xorm:"TEXT"`
+	ForbiddenCommitMessagePatterns []string `xorm:"JSON TEXT"`
+	MaxCommitSubjectLength         int      `xorm:"NOT NULL DEFAULT 0"`
+	RequiredCommitTrailers         []string `xorm:"JSON TEXT"`
+
+	CreatedUnix timeutil.TimeStamp `xorm:"created"`
+	UpdatedUnix timeutil.TimeStamp `xorm:"updated"`
+}
+
+// IsProtected returns if the branch is protected
+func (protectBranch *ProtectedBranch) IsProtected() bool {
+	return protectBranch.ID > 0
+}
+
+// CanUserPush returns if some user could push to this protected branch
+func (protectBranch *ProtectedBranch) CanUserPush(userID int64) bool {
+	if !protectBranch.EnableWhitelist {
+		return false
+	}
+	for _, id := range protectBranch.WhitelistUserIDs {
+		if id == userID {
+			return true
+		}
+	}
+	if len(protectBranch.WhitelistTeamIDs) == 0 {
+		return false
+	}
+	in, err := IsUserInTeams(userID, protectBranch.WhitelistTeamIDs)
+	if err != nil {
+		log.Error("IsUserInTeams: %v", err)
+		return false
+	}
+	return in
+}
+
+// GetProtectedFilePatterns parses a semicolon separated list of protected
+// file path glob patterns.
+func (protectBranch *ProtectedBranch) GetProtectedFilePatterns() []glob.Glob {
+	extarr := make([]glob.Glob, 0, 4)
+	for _, expr := range strings.Split(strings.ToLower(protectBranch.ProtectedFilePatterns), ";") {
+		expr = strings.TrimSpace(expr)
+		if expr == "" {
+			continue
+		}
+		if g, err := glob.Compile(expr, '.', '/'); err != nil {
+			log.Info("Invalid glob expression '%s' (skipped): %v", expr, err)
+		} else {
+			extarr = append(extarr, g)
+		}
+	}
+	return extarr
+}
+
+// GetProtectedBranchBy getting protected branch by repo ID and branch name
+func GetProtectedBranchBy(repoID int64, branchName string) (*ProtectedBranch, error) {
+	rel := &ProtectedBranch{RepoID: repoID, BranchName: branchName}
+	has, err := x.Get(rel)
+	if err != nil {
+		return nil, err
+	}
+	if !has {
+		return nil, nil
+	}
+	return rel, nil
+}
+
+// GetProtectedBranchesByRepoID loads every protected branch of a repository
+// into memory, so a push touching several refs pays one query instead of one
+// GetProtectedBranchBy call per ref.
+func GetProtectedBranchesByRepoID(repoID int64) ([]*ProtectedBranch, error) {
+	protectedBranches := make([]*ProtectedBranch, 0)
+	return protectedBranches, x.Where("repo_id = ?", repoID).Find(&protectedBranches)
+}
+
+// UpdateProtectedBranch saves the protected branch settings, inserting a new
+// row if protectBranch.ID is zero.
+func UpdateProtectedBranch(protectBranch *ProtectedBranch) error {
+	if protectBranch.ID == 0 {
+		_, err := x.Insert(protectBranch)
+		return err
+	}
+	_, err := x.ID(protectBranch.ID).AllCols().Update(protectBranch)
+	return err
+}
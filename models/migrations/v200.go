@@ -0,0 +1,21 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import (
+	"xorm.io/xorm"
+)
+
+// addPushOptionPolicyToProtectedBranch adds the allow/deny list of required
+// and forbidden `git push -o` option keys to protected_branch. Registered in
+// the migrations list as the entry after the most recent one.
+func addPushOptionPolicyToProtectedBranch(x *xorm.Engine) error {
+	type ProtectedBranch struct {
+		RequiredPushOptions  []string `xorm:"JSON TEXT"`
+		ForbiddenPushOptions []string `xorm:"JSON TEXT"`
+	}
+
+	return x.Sync2(new(ProtectedBranch))
+}
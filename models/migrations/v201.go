@@ -0,0 +1,23 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import (
+	"xorm.io/xorm"
+)
+
+// addCommitMessagePolicyToProtectedBranch adds the commit-message and
+// trailer policy columns to protected_branch. Registered in the migrations
+// list directly after addPushOptionPolicyToProtectedBranch.
+func addCommitMessagePolicyToProtectedBranch(x *xorm.Engine) error {
+	type ProtectedBranch struct {
+		CommitMessageRegex             string   `xorm:"TEXT"`
+		ForbiddenCommitMessagePatterns []string `xorm:"JSON TEXT"`
+		MaxCommitSubjectLength         int      `xorm:"NOT NULL DEFAULT 0"`
+		RequiredCommitTrailers         []string `xorm:"JSON TEXT"`
+	}
+
+	return x.Sync2(new(ProtectedBranch))
+}
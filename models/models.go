@@ -0,0 +1,27 @@
+// Copyright 2014 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"code.gitea.io/gitea/models/quota"
+
+	"xorm.io/xorm"
+)
+
+// tables holds every table a models subpackage asks to have synced on
+// startup, in addition to the core tables NewEngine already syncs.
+var tables []interface{}
+
+func init() {
+	tables = append(tables, quota.Tables...)
+}
+
+// syncExtraTables syncs the tables contributed by models subpackages and
+// hands each subpackage the shared engine, called from NewEngine once the
+// database connection is established.
+func syncExtraTables(x *xorm.Engine) error {
+	quota.SetEngine(x)
+	return x.Sync2(tables...)
+}
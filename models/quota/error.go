@@ -0,0 +1,26 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package quota
+
+import "fmt"
+
+// ErrQuotaExceeded represents an error that a push would exceed the owner's
+// configured storage quota.
+type ErrQuotaExceeded struct {
+	OwnerName string
+	Used      int64
+	Incoming  int64
+	Limit     int64
+}
+
+func (err ErrQuotaExceeded) Error() string {
+	return fmt.Sprintf("quota exceeded: %d of %d bytes used for %s, push adds %d more bytes", err.Used, err.Limit, err.OwnerName, err.Incoming)
+}
+
+// IsErrQuotaExceeded checks if an error is an ErrQuotaExceeded
+func IsErrQuotaExceeded(err error) bool {
+	_, ok := err.(ErrQuotaExceeded)
+	return ok
+}
@@ -0,0 +1,75 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+// Package quota provides per-owner (user or organization) Git storage
+// limits, enforced when a push would introduce new objects.
+package quota
+
+import (
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"xorm.io/xorm"
+)
+
+// Rule is the configured byte limit for a single repository owner (a user or
+// an organization, both of which are `models.User` rows). An owner without a
+// Rule falls back to the configured [repository] defaults.
+type Rule struct {
+	ID          int64              `xorm:"pk autoincr"`
+	OwnerID     int64              `xorm:"UNIQUE NOT NULL"`
+	SizeLimit   int64              `xorm:"NOT NULL DEFAULT 0"`
+	UpdatedUnix timeutil.TimeStamp `xorm:"updated"`
+}
+
+// TableName sets the table name for the Rule model
+func (Rule) TableName() string {
+	return "quota_rule"
+}
+
+var x *xorm.Engine
+
+// SetEngine injects the shared xorm engine, called once from models.NewEngine
+// during startup so this subsystem's queries run against the same database.
+func SetEngine(engine *xorm.Engine) {
+	x = engine
+}
+
+// Tables are the tables this subsystem asks models.NewEngine to sync.
+var Tables = []interface{}{
+	new(Rule),
+}
+
+// GetLimit returns the effective byte limit for ownerID, falling back to
+// setting.Repository.DefaultUserQuota / DefaultOrgQuota when the owner has no
+// explicit rule. A limit of 0 or less means unlimited.
+func GetLimit(ownerID int64, isOrg bool) (int64, error) {
+	rule := new(Rule)
+	has, err := x.Where("owner_id = ?", ownerID).Get(rule)
+	if err != nil {
+		return 0, err
+	}
+	if has {
+		return rule.SizeLimit, nil
+	}
+	if isOrg {
+		return setting.Repository.DefaultOrgQuota, nil
+	}
+	return setting.Repository.DefaultUserQuota, nil
+}
+
+// SetLimit creates or updates the byte limit for ownerID, overriding the
+// configured defaults for a single user or organization.
+func SetLimit(ownerID, sizeLimit int64) error {
+	has, err := x.Where("owner_id = ?", ownerID).Exist(new(Rule))
+	if err != nil {
+		return err
+	}
+	if has {
+		_, err = x.Where("owner_id = ?", ownerID).Cols("size_limit").Update(&Rule{SizeLimit: sizeLimit})
+		return err
+	}
+	_, err = x.Insert(&Rule{OwnerID: ownerID, SizeLimit: sizeLimit})
+	return err
+}
@@ -0,0 +1,53 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package quota
+
+import (
+	"strconv"
+	"strings"
+
+	"code.gitea.io/gitea/modules/git"
+)
+
+// IncomingPushSize returns the number of bytes of new Git objects that a push
+// updating oldCommitIDs to newCommitIDs introduces into repoPath, via a
+// single `git rev-list --objects --disk-usage <new...> --not <old...>` walk
+// across every ref in the push. Computing this once for the whole push,
+// rather than once per ref, means objects shared by two updated refs (e.g. a
+// branch and a tag pointing at the same new commit) are only counted once.
+// A ref creation (old == git.EmptySHA) contributes no exclusion; a ref
+// deletion (new == git.EmptySHA) contributes no tip, since it introduces no
+// objects.
+func IncomingPushSize(repoPath string, env []string, oldCommitIDs, newCommitIDs []string) (int64, error) {
+	var tips []string
+	for _, sha := range newCommitIDs {
+		if sha != git.EmptySHA {
+			tips = append(tips, sha)
+		}
+	}
+	if len(tips) == 0 {
+		return 0, nil
+	}
+
+	var excludes []string
+	for _, sha := range oldCommitIDs {
+		if sha != git.EmptySHA {
+			excludes = append(excludes, sha)
+		}
+	}
+
+	args := append([]string{"rev-list", "--objects", "--disk-usage"}, tips...)
+	if len(excludes) > 0 {
+		args = append(args, "--not")
+		args = append(args, excludes...)
+	}
+
+	stdout, err := git.NewCommand(args...).RunInDirWithEnv(repoPath, env)
+	if err != nil {
+		return 0, err
+	}
+
+	return strconv.ParseInt(strings.TrimSpace(stdout), 10, 64)
+}
@@ -0,0 +1,196 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+// BatchCatFile wraps a single long-lived `git cat-file --batch` process for a
+// repository. Looking up many objects by SHA through this shared process
+// avoids the cost of forking a fresh `git cat-file` subprocess per object,
+// which matters when verifying every commit in a large push.
+type BatchCatFile struct {
+	repo   *Repository
+	stdin  io.WriteCloser
+	stdout *bufio.Reader
+	cancel context.CancelFunc
+	done   chan error
+	mu     sync.Mutex
+}
+
+// NewBatchCatFile starts `git cat-file --batch` in repo.Path with env applied
+// and returns a BatchCatFile ready to serve Commit lookups. The caller must
+// call Close once done with it to release the underlying process.
+func NewBatchCatFile(repo *Repository, env []string) (*BatchCatFile, error) {
+	stdinReader, stdinWriter, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+	stdoutReader, stdoutWriter, err := os.Pipe()
+	if err != nil {
+		_ = stdinReader.Close()
+		_ = stdinWriter.Close()
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- NewCommand("cat-file", "--batch").
+			RunInDirTimeoutEnvFullPipelineFunc(env, -1, repo.Path,
+				stdoutWriter, stdinReader, nil,
+				func(_ context.Context, _ context.CancelFunc) error {
+					<-ctx.Done()
+					return nil
+				})
+		_ = stdoutWriter.Close()
+	}()
+
+	return &BatchCatFile{
+		repo:   repo,
+		stdin:  stdinWriter,
+		stdout: bufio.NewReader(stdoutReader),
+		cancel: cancel,
+		done:   done,
+	}, nil
+}
+
+// objectHeader is the parsed "<sha> <type> <size>" line a cat-file --batch
+// request yields before the object's content.
+type objectHeader struct {
+	sha  string
+	typ  string
+	size int64
+}
+
+// header requests sha from the batch process and parses its response header.
+// Callers must consume exactly header.size+1 bytes of stdout afterwards,
+// via readContent or discardContent, to keep the stream in sync.
+func (b *BatchCatFile) header(sha string) (*objectHeader, error) {
+	if _, err := b.stdin.Write([]byte(sha + "\n")); err != nil {
+		return nil, fmt.Errorf("unable to request %s from cat-file --batch: %v", sha, err)
+	}
+
+	line, err := b.stdout.ReadString('\n')
+	if err != nil {
+		return nil, fmt.Errorf("unable to read cat-file --batch header for %s: %v", sha, err)
+	}
+	fields := strings.Fields(line)
+	if len(fields) >= 2 && fields[1] == "missing" {
+		return nil, fmt.Errorf("object %s does not exist", sha)
+	}
+	if len(fields) < 3 {
+		return nil, fmt.Errorf("unexpected cat-file --batch header for %s: %q", sha, line)
+	}
+	size, err := strconv.ParseInt(fields[2], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid size in cat-file --batch header for %s: %q", sha, line)
+	}
+	return &objectHeader{sha: fields[0], typ: fields[1], size: size}, nil
+}
+
+// readContent reads header.size bytes of object content plus the trailing
+// newline cat-file --batch appends after every object.
+func (b *BatchCatFile) readContent(header *objectHeader) ([]byte, error) {
+	data := make([]byte, header.size)
+	if _, err := io.ReadFull(b.stdout, data); err != nil {
+		return nil, fmt.Errorf("short read of object %s (wanted %d bytes): %v", header.sha, header.size, err)
+	}
+	if _, err := b.stdout.Discard(1); err != nil {
+		return nil, fmt.Errorf("unable to discard trailing newline for %s: %v", header.sha, err)
+	}
+	return data, nil
+}
+
+// discardContent drains header.size+1 bytes of object content and its
+// trailing newline without buffering them, for objects the caller doesn't
+// want to read in full (e.g. an oversize blob).
+func (b *BatchCatFile) discardContent(header *objectHeader) error {
+	if _, err := io.CopyN(ioutil.Discard, b.stdout, header.size+1); err != nil {
+		return fmt.Errorf("unable to discard object %s: %v", header.sha, err)
+	}
+	return nil
+}
+
+// Commit requests sha from the batch process and parses the resulting
+// "<sha> <type> <size>\n<content>\n" frame into a *Commit. It returns an
+// error if sha does not name a commit object, if the batch process reports
+// the object missing, or on a short read of the framed content.
+func (b *BatchCatFile) Commit(sha string) (*Commit, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	header, err := b.header(sha)
+	if err != nil {
+		return nil, err
+	}
+	if header.typ != "commit" {
+		if err := b.discardContent(header); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("object %s is a %s, not a commit", sha, header.typ)
+	}
+
+	data, err := b.readContent(header)
+	if err != nil {
+		return nil, err
+	}
+	return CommitFromReader(b.repo, plumbing.NewHash(header.sha), bytes.NewReader(data))
+}
+
+// Blob requests sha from the batch process and returns its size in bytes. If
+// the blob is no larger than maxContent its content is returned too;
+// otherwise content is nil and the data is drained without ever being
+// buffered in memory, which matters for rejecting oversize pushed files
+// without reading them fully. It returns an error if sha does not name a
+// blob.
+func (b *BatchCatFile) Blob(sha string, maxContent int64) (size int64, content []byte, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	header, err := b.header(sha)
+	if err != nil {
+		return 0, nil, err
+	}
+	if header.typ != "blob" {
+		if err := b.discardContent(header); err != nil {
+			return 0, nil, err
+		}
+		return 0, nil, fmt.Errorf("object %s is a %s, not a blob", sha, header.typ)
+	}
+
+	if maxContent >= 0 && header.size > maxContent {
+		if err := b.discardContent(header); err != nil {
+			return 0, nil, err
+		}
+		return header.size, nil, nil
+	}
+
+	content, err = b.readContent(header)
+	return header.size, content, err
+}
+
+// Close terminates the underlying cat-file --batch process and waits for it
+// to exit.
+func (b *BatchCatFile) Close() error {
+	b.cancel()
+	closeErr := b.stdin.Close()
+	if err := <-b.done; err != nil && closeErr == nil {
+		closeErr = err
+	}
+	return closeErr
+}
@@ -0,0 +1,126 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package git
+
+import (
+	"bufio"
+	"io"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeBatchCatFile builds a BatchCatFile whose backing process is a pipe
+// pair driven by hand, so tests can exercise short reads and cancellation
+// without a real git binary or repository fixture.
+func fakeBatchCatFile(t *testing.T) (b *BatchCatFile, serverStdin io.ReadCloser, serverStdout io.WriteCloser) {
+	stdinReader, stdinWriter, err := os.Pipe()
+	assert.NoError(t, err)
+	stdoutReader, stdoutWriter, err := os.Pipe()
+	assert.NoError(t, err)
+
+	b = &BatchCatFile{
+		stdin:  stdinWriter,
+		stdout: bufio.NewReader(stdoutReader),
+		cancel: func() {},
+		done:   make(chan error, 1),
+	}
+	b.done <- nil
+
+	return b, stdinReader, stdoutWriter
+}
+
+func TestBatchCatFileShortRead(t *testing.T) {
+	b, serverStdin, serverStdout := fakeBatchCatFile(t)
+	defer serverStdin.Close()
+
+	go func() {
+		_, _ = ioutil.ReadAll(serverStdin)
+	}()
+
+	// Header claims 200 bytes of content but only a handful are ever
+	// written before the pipe is closed.
+	_, _ = serverStdout.Write([]byte("abc123 commit 200\nshort"))
+	_ = serverStdout.Close()
+
+	_, err := b.Commit("abc123")
+	assert.Error(t, err)
+}
+
+func TestBatchCatFileOversizeCommit(t *testing.T) {
+	b, serverStdin, serverStdout := fakeBatchCatFile(t)
+	defer serverStdin.Close()
+	defer serverStdout.Close()
+
+	go func() {
+		_, _ = ioutil.ReadAll(serverStdin)
+	}()
+
+	// A commit object much larger than any real commit should still be
+	// read in full rather than truncated.
+	body := strings.Repeat("a", 1<<20)
+	header := "abc123 commit " + strconv.Itoa(len(body)) + "\n"
+	go func() {
+		_, _ = serverStdout.Write([]byte(header + body + "\n"))
+	}()
+
+	_, err := b.Commit("abc123")
+	// The synthetic body is not a parseable commit object, but it must be
+	// read in full (no short read error) before CommitFromReader rejects it.
+	if err != nil {
+		assert.NotContains(t, err.Error(), "short read")
+	}
+}
+
+func TestBatchCatFileMissingObject(t *testing.T) {
+	b, serverStdin, serverStdout := fakeBatchCatFile(t)
+	defer serverStdin.Close()
+
+	go func() {
+		_, _ = ioutil.ReadAll(serverStdin)
+	}()
+
+	_, _ = serverStdout.Write([]byte("abc123 missing\n"))
+	_ = serverStdout.Close()
+
+	_, err := b.Commit("abc123")
+	assert.Error(t, err)
+}
+
+func TestBatchCatFileBlobOversizeSkipsContent(t *testing.T) {
+	b, serverStdin, serverStdout := fakeBatchCatFile(t)
+	defer serverStdin.Close()
+
+	go func() {
+		_, _ = ioutil.ReadAll(serverStdin)
+	}()
+
+	body := strings.Repeat("a", 100)
+	header := "abc123 blob " + strconv.Itoa(len(body)) + "\n"
+	go func() {
+		_, _ = serverStdout.Write([]byte(header + body + "\n"))
+	}()
+
+	size, content, err := b.Blob("abc123", 10)
+	assert.NoError(t, err)
+	assert.EqualValues(t, len(body), size)
+	assert.Nil(t, content)
+}
+
+func TestBatchCatFileCloseCancelsMidBatch(t *testing.T) {
+	b, serverStdin, serverStdout := fakeBatchCatFile(t)
+	defer serverStdin.Close()
+	defer serverStdout.Close()
+
+	cancelled := false
+	b.cancel = func() { cancelled = true }
+
+	assert.NoError(t, b.Close())
+	assert.True(t, cancelled)
+}
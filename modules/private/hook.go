@@ -0,0 +1,46 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package private
+
+// Git environment variables
+const (
+	GitAlternativeObjectDirectories = "GIT_ALTERNATIVE_OBJECT_DIRECTORIES"
+	GitObjectDirectory              = "GIT_OBJECT_DIRECTORY"
+	GitQuarantinePath               = "GIT_QUARANTINE_PATH"
+	GitPushOptionCount              = "GIT_PUSH_OPTION_COUNT"
+)
+
+// HookOptions represents the options for the Hook calls
+type HookOptions struct {
+	OldCommitIDs                    []string
+	NewCommitIDs                    []string
+	RefFullNames                    []string
+	UserID                          int64
+	UserName                        string
+	GitObjectDirectory              string
+	GitQuarantinePath               string
+	GitAlternativeObjectDirectories string
+	ProtectedBranchID               int64
+	IsDeployKey                     bool
+	// PushOptions holds the `key=value` pairs passed via `git push -o`, as
+	// forwarded by the pre-receive/post-receive hook scripts from the
+	// GIT_PUSH_OPTION_COUNT/GIT_PUSH_OPTION_N environment variables.
+	PushOptions map[string]string
+}
+
+// HookPostReceiveResult represents an individual result from PostReceive
+type HookPostReceiveResult struct {
+	Results      []HookPostReceiveBranchResult
+	RepoWasEmpty bool
+	Err          string
+}
+
+// HookPostReceiveBranchResult represents an individual branch result from PostReceive
+type HookPostReceiveBranchResult struct {
+	Message bool
+	Create  bool
+	Branch  string
+	URL     string
+}
@@ -0,0 +1,51 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package private
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+
+	"code.gitea.io/gitea/modules/setting"
+)
+
+// Hook calls one of the internal /api/internal/hook/<kind> endpoints served
+// by routers/private with opts as the JSON body, returning the raw response
+// body. kind is "pre-receive" or "post-receive".
+func Hook(kind, ownerName, repoName string, opts HookOptions) ([]byte, error) {
+	reqBody, err := json.Marshal(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	reqURL := fmt.Sprintf("%sapi/internal/hook/%s/%s/%s", setting.LocalURL, kind,
+		url.PathEscape(ownerName), url.PathEscape(repoName))
+
+	req, err := http.NewRequest("POST", reqURL, bytes.NewReader(reqBody))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+setting.InternalToken)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("unable to call internal %s hook: %v", kind, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return body, fmt.Errorf("internal %s hook returned status %d: %s", kind, resp.StatusCode, body)
+	}
+	return body, nil
+}
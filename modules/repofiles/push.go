@@ -0,0 +1,70 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package repofiles
+
+import (
+	"strings"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/git"
+	notify_service "code.gitea.io/gitea/modules/notification"
+)
+
+// PushUpdateOptions defines the options for an update to a single ref from a
+// push.
+type PushUpdateOptions struct {
+	PusherID     int64
+	PusherName   string
+	RepoUserName string
+	RepoName     string
+	RefFullName  string
+	OldCommitID  string
+	NewCommitID  string
+	// SkipCI suppresses webhook/CI dispatch for this update, set via the
+	// `ci.skip=true` git push option.
+	SkipCI bool
+	// Topic tags this update with a short name, set via the `topic=<name>`
+	// git push option.
+	Topic string
+}
+
+// IsNewBranch return true if this push created the branch
+func (opts *PushUpdateOptions) IsNewBranch() bool {
+	return opts.IsBranch() && opts.OldCommitID == git.EmptySHA
+}
+
+// IsDelRef return true if this push deleted the ref
+func (opts *PushUpdateOptions) IsDelRef() bool {
+	return opts.NewCommitID == git.EmptySHA
+}
+
+// IsBranch returns true if the ref updated is a branch
+func (opts *PushUpdateOptions) IsBranch() bool {
+	return strings.HasPrefix(opts.RefFullName, git.BranchPrefix)
+}
+
+// IsTag returns true if the ref updated is a tag
+func (opts *PushUpdateOptions) IsTag() bool {
+	return strings.HasPrefix(opts.RefFullName, git.TagPrefix)
+}
+
+// BranchName returns the name of the branch being pushed to, with the
+// `refs/heads/` prefix stripped
+func (opts *PushUpdateOptions) BranchName() string {
+	return strings.TrimPrefix(opts.RefFullName, git.BranchPrefix)
+}
+
+// PushUpdates updates the repository's activity/webhook/CI state for a batch
+// of ref updates from a single push. An update with SkipCI set, like a ref
+// deletion, is skipped: no webhook or CI notification is sent for it.
+func PushUpdates(repo *models.Repository, optsList []*PushUpdateOptions) error {
+	for _, opts := range optsList {
+		if opts.IsDelRef() || opts.SkipCI {
+			continue
+		}
+		notify_service.NotifyPushCommits(repo, opts.PusherID, opts.RefFullName, opts.OldCommitID, opts.NewCommitID, opts.Topic)
+	}
+	return nil
+}
@@ -0,0 +1,36 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package setting
+
+// Repository settings loaded from the [repository] section of app.ini.
+var Repository = struct {
+	// DefaultUserQuota is the default byte limit for a repository owned by a
+	// user: a push is rejected when the repository's size plus the size of
+	// the objects the push introduces would exceed it. Each repository is
+	// checked on its own; the owner's other repositories are not counted.
+	// 0 means unlimited. Overridden per-owner via quota.SetLimit.
+	DefaultUserQuota int64
+	// DefaultOrgQuota is the same default byte limit for a repository owned
+	// by an organization. 0 means unlimited. Overridden per-owner via
+	// quota.SetLimit.
+	DefaultOrgQuota int64
+	// MaxPushFileSize is the largest single blob a push may introduce, in
+	// bytes. 0 means unlimited. It is checked only for refs that update a
+	// protected branch; pushes to other refs are not size-checked.
+	MaxPushFileSize int64
+}{
+	DefaultUserQuota: 0,
+	DefaultOrgQuota:  0,
+	MaxPushFileSize:  0,
+}
+
+// newRepository populates Repository from app.ini. Called from NewContext
+// alongside the package's other newXxx section loaders.
+func newRepository() {
+	sec := Cfg.Section("repository")
+	Repository.DefaultUserQuota = sec.Key("DEFAULT_USER_QUOTA").MustInt64(0)
+	Repository.DefaultOrgQuota = sec.Key("DEFAULT_ORG_QUOTA").MustInt64(0)
+	Repository.MaxPushFileSize = sec.Key("MAX_PUSH_FILE_SIZE").MustInt64(0)
+}
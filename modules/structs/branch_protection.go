@@ -0,0 +1,32 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package structs
+
+// BranchProtection represents a branch protection for a repository
+type BranchProtection struct {
+	BranchName string `json:"branch_name"`
+	// CommitMessageRegex is a regular expression the subject line of every
+	// commit on this branch must match.
+	CommitMessageRegex string `json:"commit_message_regex"`
+	// ForbiddenCommitMessagePatterns rejects a push if any commit's full
+	// message matches one of these regular expressions.
+	ForbiddenCommitMessagePatterns []string `json:"forbidden_commit_message_patterns"`
+	// MaxCommitSubjectLength rejects a push if any commit's subject line is
+	// longer than this many characters. 0 means unchecked.
+	MaxCommitSubjectLength int `json:"max_commit_subject_length"`
+	// RequiredCommitTrailers rejects a push unless every commit's message
+	// contains each of these trailers.
+	RequiredCommitTrailers []string `json:"required_commit_trailers"`
+}
+
+// EditBranchProtectionOption options for editing a branch's commit-message
+// policy. Pointer fields are only applied when set, so a caller can update a
+// single setting without resending the rest.
+type EditBranchProtectionOption struct {
+	CommitMessageRegex             *string   `json:"commit_message_regex"`
+	ForbiddenCommitMessagePatterns *[]string `json:"forbidden_commit_message_patterns"`
+	MaxCommitSubjectLength         *int      `json:"max_commit_subject_length"`
+	RequiredCommitTrailers         *[]string `json:"required_commit_trailers"`
+}
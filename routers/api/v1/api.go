@@ -0,0 +1,29 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package v1
+
+import (
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/routers/api/v1/repo"
+
+	"gitea.com/macaron/binding"
+	"gitea.com/macaron/macaron"
+)
+
+// bind binds request data to obj, returning the invalid-form error response
+// used by every API write endpoint if validation fails.
+func bind(obj interface{}) macaron.Handler {
+	return binding.Bind(obj)
+}
+
+// RegisterBranchProtectionRoutes wires the commit-message policy endpoints
+// under "/repos/:username/:reponame/branch_protections/:name".
+func RegisterBranchProtectionRoutes(m *macaron.Macaron) {
+	m.Group("/repos/:username/:reponame/branch_protections/:name", func() {
+		m.Get("", repo.GetBranchProtection)
+		m.Patch("", bind(structs.EditBranchProtectionOption{}), repo.EditBranchProtection)
+	}, context.ReqToken(), context.RepoAssignment(), context.RequireRepoAdmin())
+}
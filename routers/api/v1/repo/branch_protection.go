@@ -0,0 +1,74 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package repo
+
+import (
+	"net/http"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/structs"
+)
+
+// GetBranchProtection gets the commit-message policy of a protected branch
+func GetBranchProtection(ctx *context.APIContext) {
+	repo := ctx.Repo.Repository
+	bpName := ctx.Params(":name")
+	bp, err := models.GetProtectedBranchBy(repo.ID, bpName)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "GetProtectedBranchBy", err)
+		return
+	}
+	if bp == nil || !bp.IsProtected() {
+		ctx.NotFound()
+		return
+	}
+	ctx.JSON(http.StatusOK, toBranchProtection(bp))
+}
+
+// EditBranchProtection updates the commit-message policy of a protected
+// branch, applying only the fields the caller set.
+func EditBranchProtection(ctx *context.APIContext, form structs.EditBranchProtectionOption) {
+	repo := ctx.Repo.Repository
+	bpName := ctx.Params(":name")
+	bp, err := models.GetProtectedBranchBy(repo.ID, bpName)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "GetProtectedBranchBy", err)
+		return
+	}
+	if bp == nil || !bp.IsProtected() {
+		ctx.NotFound()
+		return
+	}
+
+	if form.CommitMessageRegex != nil {
+		bp.CommitMessageRegex = *form.CommitMessageRegex
+	}
+	if form.ForbiddenCommitMessagePatterns != nil {
+		bp.ForbiddenCommitMessagePatterns = *form.ForbiddenCommitMessagePatterns
+	}
+	if form.MaxCommitSubjectLength != nil {
+		bp.MaxCommitSubjectLength = *form.MaxCommitSubjectLength
+	}
+	if form.RequiredCommitTrailers != nil {
+		bp.RequiredCommitTrailers = *form.RequiredCommitTrailers
+	}
+
+	if err := models.UpdateProtectedBranch(bp); err != nil {
+		ctx.Error(http.StatusInternalServerError, "UpdateProtectedBranch", err)
+		return
+	}
+	ctx.JSON(http.StatusOK, toBranchProtection(bp))
+}
+
+func toBranchProtection(bp *models.ProtectedBranch) *structs.BranchProtection {
+	return &structs.BranchProtection{
+		BranchName:                     bp.BranchName,
+		CommitMessageRegex:             bp.CommitMessageRegex,
+		ForbiddenCommitMessagePatterns: bp.ForbiddenCommitMessagePatterns,
+		MaxCommitSubjectLength:         bp.MaxCommitSubjectLength,
+		RequiredCommitTrailers:         bp.RequiredCommitTrailers,
+	}
+}
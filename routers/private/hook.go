@@ -9,12 +9,15 @@ import (
 	"bufio"
 	"context"
 	"fmt"
-	"io"
 	"net/http"
 	"os"
+	"regexp"
+	"strconv"
 	"strings"
+	"unicode/utf8"
 
 	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/models/quota"
 	"code.gitea.io/gitea/modules/git"
 	"code.gitea.io/gitea/modules/log"
 	"code.gitea.io/gitea/modules/private"
@@ -24,11 +27,15 @@ import (
 	pull_service "code.gitea.io/gitea/services/pull"
 
 	"gitea.com/macaron/macaron"
-	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/gobwas/glob"
 )
 
-func verifyCommits(oldCommitID, newCommitID string, repo *git.Repository, env []string) error {
+// checkCommits verifies every commit introduced by oldCommitID..newCommitID
+// against a protected branch's required-signature and commit-message
+// policies, in a single `git rev-list` walk over a single long-lived `git
+// cat-file --batch` process, rather than giving each check its own walk and
+// batch process over the identical commit range.
+func checkCommits(oldCommitID, newCommitID string, protectBranch *models.ProtectedBranch, requireSignedCommits bool, repo *git.Repository, env []string) error {
 	stdoutReader, stdoutWriter, err := os.Pipe()
 	if err != nil {
 		log.Error("Unable to create os.Pipe for %s", repo.Path)
@@ -39,20 +46,41 @@ func verifyCommits(oldCommitID, newCommitID string, repo *git.Repository, env []
 		_ = stdoutWriter.Close()
 	}()
 
+	batch, err := git.NewBatchCatFile(repo, env)
+	if err != nil {
+		log.Error("Unable to open cat-file --batch for %s: %v", repo.Path, err)
+		return err
+	}
+	defer batch.Close()
+
 	err = git.NewCommand("rev-list", oldCommitID+"..."+newCommitID).
 		RunInDirTimeoutEnvFullPipelineFunc(env, -1, repo.Path,
 			stdoutWriter, nil, nil,
 			func(ctx context.Context, cancel context.CancelFunc) error {
 				_ = stdoutWriter.Close()
-				err := readAndVerifyCommitsFromShaReader(stdoutReader, repo, env)
-				if err != nil {
-					log.Error("%v", err)
-					cancel()
+				scanner := bufio.NewScanner(stdoutReader)
+				for scanner.Scan() {
+					sha := scanner.Text()
+					commit, err := batch.Commit(sha)
+					if err != nil {
+						return err
+					}
+					if requireSignedCommits {
+						verification := models.ParseCommitWithSignature(commit)
+						if !verification.Verified {
+							cancel()
+							return &errUnverifiedCommit{commit.ID.String()}
+						}
+					}
+					if rule, ok := checkCommitAgainstPolicy(protectBranch, commit); !ok {
+						cancel()
+						return &errCommitPolicyViolation{sha: commit.ID.String(), rule: rule}
+					}
 				}
 				_ = stdoutReader.Close()
-				return err
+				return scanner.Err()
 			})
-	if err != nil && !isErrUnverifiedCommit(err) {
+	if err != nil && !isErrUnverifiedCommit(err) && !isErrCommitPolicyViolation(err) {
 		log.Error("Unable to check commits from %s to %s in %s: %v", oldCommitID, newCommitID, repo.Path, err)
 	}
 	return err
@@ -104,49 +132,246 @@ func checkFileProtection(oldCommitID, newCommitID string, patterns []glob.Glob,
 	return err
 }
 
-func readAndVerifyCommitsFromShaReader(input io.ReadCloser, repo *git.Repository, env []string) error {
-	scanner := bufio.NewScanner(input)
-	for scanner.Scan() {
-		line := scanner.Text()
-		err := readAndVerifyCommit(line, repo, env)
+// errCommitPolicyViolation is returned when a commit fails a protected
+// branch's commit-message policy.
+type errCommitPolicyViolation struct {
+	sha  string
+	rule string
+}
+
+func (e *errCommitPolicyViolation) Error() string {
+	return fmt.Sprintf("commit %s violates rule %q", e.sha, e.rule)
+}
+
+func isErrCommitPolicyViolation(err error) bool {
+	_, ok := err.(*errCommitPolicyViolation)
+	return ok
+}
+
+// checkCommitAgainstPolicy reports the first rule commit violates, if any.
+// A protectBranch with no commit-message policy fields set never rejects.
+func checkCommitAgainstPolicy(protectBranch *models.ProtectedBranch, commit *git.Commit) (rule string, ok bool) {
+	message := commit.CommitMessage
+	subject := strings.SplitN(message, "\n", 2)[0]
+
+	if protectBranch.CommitMessageRegex != "" {
+		if matched, _ := regexp.MatchString(protectBranch.CommitMessageRegex, subject); !matched {
+			return fmt.Sprintf("subject does not match required format %q", protectBranch.CommitMessageRegex), false
+		}
+	}
+	for _, pattern := range protectBranch.ForbiddenCommitMessagePatterns {
+		if matched, _ := regexp.MatchString(pattern, message); matched {
+			return fmt.Sprintf("commit message matches forbidden pattern %q", pattern), false
+		}
+	}
+	if protectBranch.MaxCommitSubjectLength > 0 && utf8.RuneCountInString(subject) > protectBranch.MaxCommitSubjectLength {
+		return fmt.Sprintf("subject line longer than %d characters", protectBranch.MaxCommitSubjectLength), false
+	}
+	for _, trailer := range protectBranch.RequiredCommitTrailers {
+		if !strings.Contains(message, trailer) {
+			return fmt.Sprintf("missing required trailer %q", trailer), false
+		}
+	}
+	return "", true
+}
+
+// maxLFSPointerSize bounds how much of a blob checkLFSAndFileSize will read
+// into memory to check whether it is a valid LFS pointer. Real LFS pointer
+// files are well under this size.
+const maxLFSPointerSize = 1024
+
+var lfsPointerRegexp = regexp.MustCompile(`(?s)^version https://git-lfs\.github\.com/spec/v1\noid sha256:([0-9a-f]{64})\nsize ([0-9]+)\n$`)
+
+type lfsPointer struct {
+	oid  string
+	size int64
+}
+
+// parseLFSPointer parses content as an LFS pointer file, per the spec at
+// https://github.com/git-lfs/git-lfs/blob/main/docs/spec.md
+func parseLFSPointer(content []byte) (*lfsPointer, bool) {
+	m := lfsPointerRegexp.FindSubmatch(content)
+	if m == nil {
+		return nil, false
+	}
+	size, err := strconv.ParseInt(string(m[2]), 10, 64)
+	if err != nil {
+		return nil, false
+	}
+	return &lfsPointer{oid: string(m[1]), size: size}, true
+}
+
+// errOversizeBlob is returned when a pushed blob exceeds the configured
+// maximum push file size.
+type errOversizeBlob struct {
+	path string
+	size int64
+}
+
+func (e *errOversizeBlob) Error() string {
+	return fmt.Sprintf("file %s (%d bytes) exceeds the maximum allowed push file size", e.path, e.size)
+}
+
+// errInvalidLFSPointer is returned when a path matched by the repo's LFS
+// filters does not contain a valid LFS pointer.
+type errInvalidLFSPointer struct {
+	path string
+}
+
+func (e *errInvalidLFSPointer) Error() string {
+	return fmt.Sprintf("expected LFS pointer for %s", e.path)
+}
+
+// errMissingLFSObject is returned when a valid LFS pointer references an
+// object that has not actually been uploaded to the LFS store.
+type errMissingLFSObject struct {
+	path string
+	oid  string
+}
+
+func (e *errMissingLFSObject) Error() string {
+	return fmt.Sprintf("LFS object %s referenced by %s was not found; did you forget to `git lfs push`?", e.oid, e.path)
+}
+
+// lfsFilterPatterns returns glob patterns for the paths marked `filter=lfs`
+// in the repo's .gitattributes at newCommitID. A repo without a
+// .gitattributes file at that revision has no LFS-tracked paths.
+func lfsFilterPatterns(newCommitID, repoPath string, env []string) ([]glob.Glob, error) {
+	out, err := git.NewCommand("show", newCommitID+":.gitattributes").RunInDirWithEnv(repoPath, env)
+	if err != nil {
+		return nil, nil
+	}
+
+	var patterns []glob.Glob
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		isLFS := false
+		for _, attr := range fields[1:] {
+			if attr == "filter=lfs" {
+				isLFS = true
+				break
+			}
+		}
+		if !isLFS {
+			continue
+		}
+		pat, err := glob.Compile(strings.ToLower(fields[0]))
 		if err != nil {
-			log.Error("%v", err)
-			return err
+			log.Warn("Invalid .gitattributes LFS pattern %q at %s: %v", fields[0], newCommitID, err)
+			continue
 		}
+		patterns = append(patterns, pat)
 	}
-	return scanner.Err()
+	return patterns, nil
 }
 
-func readAndVerifyCommit(sha string, repo *git.Repository, env []string) error {
+// checkLFSAndFileSize scans every blob introduced by oldCommitID..newCommitID
+// and rejects the push if a blob exceeds setting.Repository.MaxPushFileSize,
+// or if a path matched by lfsPatterns does not contain a valid LFS pointer
+// for an object already present in the LFS metadata store.
+func checkLFSAndFileSize(oldCommitID, newCommitID string, repoID int64, repo *git.Repository, lfsPatterns []glob.Glob, env []string) error {
 	stdoutReader, stdoutWriter, err := os.Pipe()
 	if err != nil {
-		log.Error("Unable to create pipe for %s: %v", repo.Path, err)
+		log.Error("Unable to create os.Pipe for %s", repo.Path)
 		return err
 	}
 	defer func() {
 		_ = stdoutReader.Close()
 		_ = stdoutWriter.Close()
 	}()
-	hash := plumbing.NewHash(sha)
 
-	return git.NewCommand("cat-file", "commit", sha).
+	batch, err := git.NewBatchCatFile(repo, env)
+	if err != nil {
+		log.Error("Unable to open cat-file --batch for %s: %v", repo.Path, err)
+		return err
+	}
+	defer batch.Close()
+
+	err = git.NewCommand("rev-list", "--objects", oldCommitID+".."+newCommitID).
 		RunInDirTimeoutEnvFullPipelineFunc(env, -1, repo.Path,
 			stdoutWriter, nil, nil,
 			func(ctx context.Context, cancel context.CancelFunc) error {
 				_ = stdoutWriter.Close()
-				commit, err := git.CommitFromReader(repo, hash, stdoutReader)
-				if err != nil {
-					return err
-				}
-				verification := models.ParseCommitWithSignature(commit)
-				if !verification.Verified {
-					cancel()
-					return &errUnverifiedCommit{
-						commit.ID.String(),
+				scanner := bufio.NewScanner(stdoutReader)
+				for scanner.Scan() {
+					fields := strings.SplitN(strings.TrimSpace(scanner.Text()), " ", 2)
+					if len(fields) != 2 || fields[1] == "" {
+						continue // commits and the root tree carry no path
+					}
+					sha, path := fields[0], fields[1]
+
+					isLFSPath := false
+					lpath := strings.ToLower(path)
+					for _, pat := range lfsPatterns {
+						if pat.Match(lpath) {
+							isLFSPath = true
+							break
+						}
+					}
+
+					maxContent := int64(0)
+					if isLFSPath {
+						maxContent = maxLFSPointerSize
+					}
+					size, content, err := batch.Blob(sha, maxContent)
+					if err != nil {
+						if strings.Contains(err.Error(), "not a blob") {
+							continue // trees are listed by rev-list --objects too
+						}
+						return err
+					}
+
+					if setting.Repository.MaxPushFileSize > 0 && size > setting.Repository.MaxPushFileSize {
+						cancel()
+						return &errOversizeBlob{path: path, size: size}
+					}
+					if !isLFSPath {
+						continue
+					}
+
+					pointer, ok := parseLFSPointer(content)
+					if !ok {
+						cancel()
+						return &errInvalidLFSPointer{path: path}
+					}
+					if _, err := models.GetLFSMetaObjectByOid(repoID, pointer.oid); err != nil {
+						if models.IsErrLFSObjectNotExist(err) {
+							cancel()
+							return &errMissingLFSObject{path: path, oid: pointer.oid}
+						}
+						return err
 					}
 				}
-				return nil
+				return scanner.Err()
 			})
+	if err != nil {
+		switch err.(type) {
+		case *errOversizeBlob, *errInvalidLFSPointer, *errMissingLFSObject:
+		default:
+			log.Error("Unable to check LFS pointers/file sizes from %s to %s in %s: %v", oldCommitID, newCommitID, repo.Path, err)
+		}
+	}
+	return err
+}
+
+// checkPushOptionPolicy enforces a protected branch's allowlist of required
+// and forbidden `git push -o` option keys against the options supplied with
+// this push.
+func checkPushOptionPolicy(protectBranch *models.ProtectedBranch, pushOptions map[string]string) error {
+	for _, key := range protectBranch.RequiredPushOptions {
+		if _, ok := pushOptions[key]; !ok {
+			return fmt.Errorf("push option %q is required on this branch", key)
+		}
+	}
+	for _, key := range protectBranch.ForbiddenPushOptions {
+		if _, ok := pushOptions[key]; ok {
+			return fmt.Errorf("push option %q is not allowed on this branch", key)
+		}
+	}
+	return nil
 }
 
 type errUnverifiedCommit struct {
@@ -162,6 +387,84 @@ func isErrUnverifiedCommit(err error) bool {
 	return ok
 }
 
+// preReceiveContext lazily loads and memoizes the state that is shared by
+// every ref of a single push (the pusher, their permission on the repo, and
+// the repo's protected branches), so HookPreReceive pays each DB round-trip
+// once per push instead of once per ref.
+type preReceiveContext struct {
+	ctx  *macaron.Context
+	opts *private.HookOptions
+	repo *models.Repository
+
+	user       *models.User
+	userErr    error
+	userLoaded bool
+
+	perm       models.Permission
+	permErr    error
+	permLoaded bool
+
+	protectedBranches       map[string]*models.ProtectedBranch
+	protectedBranchesErr    error
+	protectedBranchesLoaded bool
+}
+
+// jsonError centralizes the JSON error response every per-ref check returns,
+// so each check site no longer repeats the same three lines.
+func (prctx *preReceiveContext) jsonError(status int, format string, args ...interface{}) {
+	prctx.ctx.JSON(status, map[string]interface{}{
+		"err": fmt.Sprintf(format, args...),
+	})
+}
+
+// User returns the memoized pusher, loading it from the database at most
+// once per push.
+func (prctx *preReceiveContext) User() (*models.User, error) {
+	if !prctx.userLoaded {
+		prctx.userLoaded = true
+		prctx.user, prctx.userErr = models.GetUserByID(prctx.opts.UserID)
+	}
+	return prctx.user, prctx.userErr
+}
+
+// Permission returns the memoized permission the pusher has on the repo,
+// loading it from the database at most once per push.
+func (prctx *preReceiveContext) Permission() (models.Permission, error) {
+	if !prctx.permLoaded {
+		prctx.permLoaded = true
+		user, err := prctx.User()
+		if err != nil {
+			prctx.permErr = err
+			return prctx.perm, prctx.permErr
+		}
+		prctx.perm, prctx.permErr = models.GetUserRepoPermission(prctx.repo, user)
+	}
+	return prctx.perm, prctx.permErr
+}
+
+// ProtectedBranch returns the memoized protected branch settings for
+// branchName, populated with a single GetProtectedBranchesByRepoID call for
+// the whole push rather than one GetProtectedBranchBy call per ref.
+func (prctx *preReceiveContext) ProtectedBranch(branchName string) (*models.ProtectedBranch, error) {
+	if !prctx.protectedBranchesLoaded {
+		prctx.protectedBranchesLoaded = true
+		branches, err := models.GetProtectedBranchesByRepoID(prctx.repo.ID)
+		if err != nil {
+			prctx.protectedBranchesErr = err
+			return nil, prctx.protectedBranchesErr
+		}
+		m := make(map[string]*models.ProtectedBranch, len(branches))
+		for _, branch := range branches {
+			m[branch.BranchName] = branch
+		}
+		prctx.protectedBranches = m
+	}
+	if prctx.protectedBranchesErr != nil {
+		return nil, prctx.protectedBranchesErr
+	}
+	return prctx.protectedBranches[branchName], nil
+}
+
 // HookPreReceive checks whether a individual commit is acceptable
 func HookPreReceive(ctx *macaron.Context, opts private.HookOptions) {
 	ownerName := ctx.Params(":owner")
@@ -185,6 +488,8 @@ func HookPreReceive(ctx *macaron.Context, opts private.HookOptions) {
 	}
 	defer gitRepo.Close()
 
+	prctx := &preReceiveContext{ctx: ctx, opts: &opts, repo: repo}
+
 	// Generate git environment for checking commits
 	env := os.Environ()
 	if opts.GitAlternativeObjectDirectories != "" {
@@ -200,27 +505,56 @@ func HookPreReceive(ctx *macaron.Context, opts private.HookOptions) {
 			private.GitQuarantinePath+"="+opts.GitQuarantinePath)
 	}
 
+	// Enforce the owner's storage quota once for the whole push, measuring the
+	// new objects of every updated ref together in one walk before deciding,
+	// rather than accepting some refs and rejecting others mid-push.
+	owner, err := models.GetUserByID(repo.OwnerID)
+	if err != nil {
+		log.Error("Unable to get owner of repository: %-v Error: %v", repo, err)
+		prctx.jsonError(http.StatusInternalServerError, "%v", err)
+		return
+	}
+	incomingSize, err := quota.IncomingPushSize(repo.RepoPath(), env, opts.OldCommitIDs, opts.NewCommitIDs)
+	if err != nil {
+		log.Error("Unable to calculate incoming push size for %-v Error: %v", repo, err)
+		prctx.jsonError(http.StatusInternalServerError, "Unable to calculate incoming push size: %v", err)
+		return
+	}
+	limit, err := quota.GetLimit(owner.ID, owner.IsOrganization())
+	if err != nil {
+		log.Error("Unable to get quota limit for owner %s Error: %v", owner.Name, err)
+		prctx.jsonError(http.StatusInternalServerError, "%v", err)
+		return
+	}
+	if limit > 0 && repo.Size+incomingSize > limit {
+		quotaErr := quota.ErrQuotaExceeded{
+			OwnerName: owner.Name,
+			Used:      repo.Size,
+			Incoming:  incomingSize,
+			Limit:     limit,
+		}
+		log.Warn("Forbidden: %v for %-v", quotaErr, repo)
+		prctx.jsonError(http.StatusRequestEntityTooLarge, "%v", quotaErr)
+		return
+	}
+
 	for i := range opts.OldCommitIDs {
 		oldCommitID := opts.OldCommitIDs[i]
 		newCommitID := opts.NewCommitIDs[i]
 		refFullName := opts.RefFullNames[i]
 
 		branchName := strings.TrimPrefix(refFullName, git.BranchPrefix)
-		protectBranch, err := models.GetProtectedBranchBy(repo.ID, branchName)
+		protectBranch, err := prctx.ProtectedBranch(branchName)
 		if err != nil {
 			log.Error("Unable to get protected branch: %s in %-v Error: %v", branchName, repo, err)
-			ctx.JSON(500, map[string]interface{}{
-				"err": err.Error(),
-			})
+			prctx.jsonError(http.StatusInternalServerError, "%v", err)
 			return
 		}
 		if protectBranch != nil && protectBranch.IsProtected() {
 			// detect and prevent deletion
 			if newCommitID == git.EmptySHA {
 				log.Warn("Forbidden: Branch: %s in %-v is protected from deletion", branchName, repo)
-				ctx.JSON(http.StatusForbidden, map[string]interface{}{
-					"err": fmt.Sprintf("branch %s is protected from deletion", branchName),
-				})
+				prctx.jsonError(http.StatusForbidden, "branch %s is protected from deletion", branchName)
 				return
 			}
 
@@ -229,37 +563,46 @@ func HookPreReceive(ctx *macaron.Context, opts private.HookOptions) {
 				output, err := git.NewCommand("rev-list", "--max-count=1", oldCommitID, "^"+newCommitID).RunInDirWithEnv(repo.RepoPath(), env)
 				if err != nil {
 					log.Error("Unable to detect force push between: %s and %s in %-v Error: %v", oldCommitID, newCommitID, repo, err)
-					ctx.JSON(http.StatusInternalServerError, map[string]interface{}{
-						"err": fmt.Sprintf("Fail to detect force push: %v", err),
-					})
+					prctx.jsonError(http.StatusInternalServerError, "Fail to detect force push: %v", err)
 					return
 				} else if len(output) > 0 {
 					log.Warn("Forbidden: Branch: %s in %-v is protected from force push", branchName, repo)
-					ctx.JSON(http.StatusForbidden, map[string]interface{}{
-						"err": fmt.Sprintf("branch %s is protected from force push", branchName),
-					})
+					prctx.jsonError(http.StatusForbidden, "branch %s is protected from force push", branchName)
 					return
-
 				}
 			}
 
-			// Require signed commits
-			if protectBranch.RequireSignedCommits {
-				err := verifyCommits(oldCommitID, newCommitID, gitRepo, env)
+			// Enforce the branch's allowlist of required/forbidden push option keys
+			if err := checkPushOptionPolicy(protectBranch, opts.PushOptions); err != nil {
+				log.Warn("Forbidden: Branch: %s in %-v rejected push options: %v", branchName, repo, err)
+				prctx.jsonError(http.StatusForbidden, "%v", err)
+				return
+			}
+
+			// Required signatures and commit-message policies are
+			// enforced together in a single rev-list walk, rather than
+			// giving each check its own walk over the identical range.
+			hasCommitPolicy := protectBranch.CommitMessageRegex != "" || len(protectBranch.ForbiddenCommitMessagePatterns) > 0 ||
+				protectBranch.MaxCommitSubjectLength > 0 || len(protectBranch.RequiredCommitTrailers) > 0
+			if protectBranch.RequireSignedCommits || hasCommitPolicy {
+				err := checkCommits(oldCommitID, newCommitID, protectBranch, protectBranch.RequireSignedCommits, gitRepo, env)
 				if err != nil {
-					if !isErrUnverifiedCommit(err) {
+					switch {
+					case isErrUnverifiedCommit(err):
+						unverifiedCommit := err.(*errUnverifiedCommit).sha
+						log.Warn("Forbidden: Branch: %s in %-v is protected from unverified commit %s", branchName, repo, unverifiedCommit)
+						prctx.jsonError(http.StatusForbidden, "branch %s is protected from unverified commit %s", branchName, unverifiedCommit)
+						return
+					case isErrCommitPolicyViolation(err):
+						violation := err.(*errCommitPolicyViolation)
+						log.Warn("Forbidden: Branch: %s in %-v commit %s violates %s", branchName, repo, violation.sha, violation.rule)
+						prctx.jsonError(http.StatusForbidden, "branch %s rejects commit %s: %s", branchName, violation.sha, violation.rule)
+						return
+					default:
 						log.Error("Unable to check commits from %s to %s in %-v: %v", oldCommitID, newCommitID, repo, err)
-						ctx.JSON(http.StatusInternalServerError, map[string]interface{}{
-							"err": fmt.Sprintf("Unable to check commits from %s to %s: %v", oldCommitID, newCommitID, err),
-						})
+						prctx.jsonError(http.StatusInternalServerError, "Unable to check commits from %s to %s: %v", oldCommitID, newCommitID, err)
 						return
 					}
-					unverifiedCommit := err.(*errUnverifiedCommit).sha
-					log.Warn("Forbidden: Branch: %s in %-v is protected from unverified commit %s", branchName, repo, unverifiedCommit)
-					ctx.JSON(http.StatusForbidden, map[string]interface{}{
-						"err": fmt.Sprintf("branch %s is protected from unverified commit %s", branchName, unverifiedCommit),
-					})
-					return
 				}
 			}
 
@@ -269,20 +612,41 @@ func HookPreReceive(ctx *macaron.Context, opts private.HookOptions) {
 				if err != nil {
 					if !models.IsErrFilePathProtected(err) {
 						log.Error("Unable to check file protection for commits from %s to %s in %-v: %v", oldCommitID, newCommitID, repo, err)
-						ctx.JSON(http.StatusInternalServerError, map[string]interface{}{
-							"err": fmt.Sprintf("Unable to check file protection for commits from %s to %s: %v", oldCommitID, newCommitID, err),
-						})
+						prctx.jsonError(http.StatusInternalServerError, "Unable to check file protection for commits from %s to %s: %v", oldCommitID, newCommitID, err)
 						return
 					}
 					protectedFilePath := err.(models.ErrFilePathProtected).Path
 					log.Warn("Forbidden: Branch: %s in %-v is protected from changing file %s", branchName, repo, protectedFilePath)
-					ctx.JSON(http.StatusForbidden, map[string]interface{}{
-						"err": fmt.Sprintf("branch %s is protected from changing file %s", branchName, protectedFilePath),
-					})
+					prctx.jsonError(http.StatusForbidden, "branch %s is protected from changing file %s", branchName, protectedFilePath)
 					return
 				}
 			}
 
+			// Oversize-blob rejection and LFS pointer validation
+			lfsPatterns, err := lfsFilterPatterns(newCommitID, repo.RepoPath(), env)
+			if err != nil {
+				log.Error("Unable to read .gitattributes for %-v: %v", repo, err)
+				prctx.jsonError(http.StatusInternalServerError, "Unable to read .gitattributes: %v", err)
+				return
+			}
+			if err := checkLFSAndFileSize(oldCommitID, newCommitID, repo.ID, gitRepo, lfsPatterns, env); err != nil {
+				switch v := err.(type) {
+				case *errOversizeBlob:
+					log.Warn("Forbidden: Branch: %s in %-v rejected oversize file %s (%d bytes)", branchName, repo, v.path, v.size)
+					prctx.jsonError(http.StatusRequestEntityTooLarge, "%v", v)
+				case *errInvalidLFSPointer:
+					log.Warn("Forbidden: Branch: %s in %-v rejected invalid LFS pointer at %s", branchName, repo, v.path)
+					prctx.jsonError(http.StatusForbidden, "%v", v)
+				case *errMissingLFSObject:
+					log.Warn("Forbidden: Branch: %s in %-v rejected push referencing missing LFS object %s at %s", branchName, repo, v.oid, v.path)
+					prctx.jsonError(http.StatusForbidden, "%v", v)
+				default:
+					log.Error("Unable to check LFS pointers/file sizes from %s to %s in %-v: %v", oldCommitID, newCommitID, repo, err)
+					prctx.jsonError(http.StatusInternalServerError, "Unable to check LFS pointers/file sizes from %s to %s: %v", oldCommitID, newCommitID, err)
+				}
+				return
+			}
+
 			canPush := false
 			if opts.IsDeployKey {
 				canPush = protectBranch.CanPush && (!protectBranch.EnableWhitelist || protectBranch.WhitelistDeployKeys)
@@ -294,40 +658,30 @@ func HookPreReceive(ctx *macaron.Context, opts private.HookOptions) {
 				pr, err := models.GetPullRequestByID(opts.ProtectedBranchID)
 				if err != nil {
 					log.Error("Unable to get PullRequest %d Error: %v", opts.ProtectedBranchID, err)
-					ctx.JSON(http.StatusInternalServerError, map[string]interface{}{
-						"err": fmt.Sprintf("Unable to get PullRequest %d Error: %v", opts.ProtectedBranchID, err),
-					})
+					prctx.jsonError(http.StatusInternalServerError, "Unable to get PullRequest %d Error: %v", opts.ProtectedBranchID, err)
 					return
 				}
-				user, err := models.GetUserByID(opts.UserID)
+				user, err := prctx.User()
 				if err != nil {
 					log.Error("Unable to get User id %d Error: %v", opts.UserID, err)
-					ctx.JSON(http.StatusInternalServerError, map[string]interface{}{
-						"err": fmt.Sprintf("Unable to get User id %d Error: %v", opts.UserID, err),
-					})
+					prctx.jsonError(http.StatusInternalServerError, "Unable to get User id %d Error: %v", opts.UserID, err)
 					return
 				}
-				perm, err := models.GetUserRepoPermission(repo, user)
+				perm, err := prctx.Permission()
 				if err != nil {
 					log.Error("Unable to get Repo permission of repo %s/%s of User %s", repo.OwnerName, repo.Name, user.Name, err)
-					ctx.JSON(http.StatusInternalServerError, map[string]interface{}{
-						"err": fmt.Sprintf("Unable to get Repo permission of repo %s/%s of User %s: %v", repo.OwnerName, repo.Name, user.Name, err),
-					})
+					prctx.jsonError(http.StatusInternalServerError, "Unable to get Repo permission of repo %s/%s of User %s: %v", repo.OwnerName, repo.Name, user.Name, err)
 					return
 				}
 				allowedMerge, err := pull_service.IsUserAllowedToMerge(pr, perm, user)
 				if err != nil {
 					log.Error("Error calculating if allowed to merge: %v", err)
-					ctx.JSON(http.StatusInternalServerError, map[string]interface{}{
-						"err": fmt.Sprintf("Error calculating if allowed to merge: %v", err),
-					})
+					prctx.jsonError(http.StatusInternalServerError, "Error calculating if allowed to merge: %v", err)
 					return
 				}
 				if !allowedMerge {
 					log.Warn("Forbidden: User %d is not allowed to push to protected branch: %s in %-v and is not allowed to merge pr #%d", opts.UserID, branchName, repo, pr.Index)
-					ctx.JSON(http.StatusForbidden, map[string]interface{}{
-						"err": fmt.Sprintf("Not allowed to push to protected branch %s", branchName),
-					})
+					prctx.jsonError(http.StatusForbidden, "Not allowed to push to protected branch %s", branchName)
 					return
 				}
 				// Check all status checks and reviews is ok, unless repo admin which can bypass this.
@@ -335,22 +689,16 @@ func HookPreReceive(ctx *macaron.Context, opts private.HookOptions) {
 					if err := pull_service.CheckPRReadyToMerge(pr); err != nil {
 						if models.IsErrNotAllowedToMerge(err) {
 							log.Warn("Forbidden: User %d is not allowed push to protected branch %s in %-v and pr #%d is not ready to be merged: %s", opts.UserID, branchName, repo, pr.Index, err.Error())
-							ctx.JSON(http.StatusForbidden, map[string]interface{}{
-								"err": fmt.Sprintf("Not allowed to push to protected branch %s and pr #%d is not ready to be merged: %s", branchName, opts.ProtectedBranchID, err.Error()),
-							})
+							prctx.jsonError(http.StatusForbidden, "Not allowed to push to protected branch %s and pr #%d is not ready to be merged: %s", branchName, opts.ProtectedBranchID, err.Error())
 							return
 						}
 						log.Error("Unable to check if mergable: protected branch %s in %-v and pr #%d. Error: %v", opts.UserID, branchName, repo, pr.Index, err)
-						ctx.JSON(http.StatusInternalServerError, map[string]interface{}{
-							"err": fmt.Sprintf("Unable to get status of pull request %d. Error: %v", opts.ProtectedBranchID, err),
-						})
+						prctx.jsonError(http.StatusInternalServerError, "Unable to get status of pull request %d. Error: %v", opts.ProtectedBranchID, err)
 					}
 				}
 			} else if !canPush {
 				log.Warn("Forbidden: User %d is not allowed to push to protected branch: %s in %-v", opts.UserID, branchName, repo)
-				ctx.JSON(http.StatusForbidden, map[string]interface{}{
-					"err": fmt.Sprintf("Not allowed to push to protected branch %s", branchName),
-				})
+				prctx.jsonError(http.StatusForbidden, "Not allowed to push to protected branch %s", branchName)
 				return
 			}
 		}
@@ -400,6 +748,8 @@ func HookPostReceive(ctx *macaron.Context, opts private.HookOptions) {
 				PusherName:   opts.UserName,
 				RepoUserName: ownerName,
 				RepoName:     repoName,
+				SkipCI:       opts.PushOptions["ci.skip"] == "true",
+				Topic:        opts.PushOptions["topic"],
 			}
 			updates = append(updates, &option)
 			if repo.IsEmpty && option.IsBranch() && option.BranchName() == "master" {
@@ -492,9 +842,32 @@ func HookPostReceive(ctx *macaron.Context, opts private.HookOptions) {
 			}
 
 			if pr == nil {
+				headBranch := branch
 				if repo.IsFork {
 					branch = fmt.Sprintf("%s:%s", repo.OwnerName, branch)
 				}
+
+				if opts.PushOptions["merge-request.create"] == "true" {
+					targetBranch := opts.PushOptions["merge-request.target"]
+					if targetBranch == "" {
+						targetBranch = baseRepo.DefaultBranch
+					}
+					pusher, err := models.GetUserByID(opts.UserID)
+					if err != nil {
+						log.Error("Failed to get pusher %d for auto pull request: %v", opts.UserID, err)
+					} else if newPR, err := pull_service.AutoCreatePullRequest(repo, baseRepo, headBranch, targetBranch, pusher); err != nil {
+						log.Error("Failed to auto-create pull request for push to %-v branch %s: %v", repo, branch, err)
+					} else {
+						results = append(results, private.HookPostReceiveBranchResult{
+							Message: setting.Git.PullRequestPushMessage && repo.AllowsPulls(),
+							Create:  false,
+							Branch:  branch,
+							URL:     fmt.Sprintf("%s/pulls/%d", baseRepo.HTMLURL(), newPR.Index),
+						})
+						continue
+					}
+				}
+
 				results = append(results, private.HookPostReceiveBranchResult{
 					Message: setting.Git.PullRequestPushMessage && repo.AllowsPulls(),
 					Create:  true,
@@ -0,0 +1,40 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package private
+
+import (
+	"testing"
+
+	"code.gitea.io/gitea/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckPushOptionPolicyRequiredOption(t *testing.T) {
+	protectBranch := &models.ProtectedBranch{RequiredPushOptions: []string{"ci.skip"}}
+
+	err := checkPushOptionPolicy(protectBranch, map[string]string{})
+	assert.Error(t, err)
+
+	err = checkPushOptionPolicy(protectBranch, map[string]string{"ci.skip": "true"})
+	assert.NoError(t, err)
+}
+
+func TestCheckPushOptionPolicyForbiddenOption(t *testing.T) {
+	protectBranch := &models.ProtectedBranch{ForbiddenPushOptions: []string{"merge-request.create"}}
+
+	err := checkPushOptionPolicy(protectBranch, map[string]string{"merge-request.create": "true"})
+	assert.Error(t, err)
+
+	err = checkPushOptionPolicy(protectBranch, map[string]string{})
+	assert.NoError(t, err)
+}
+
+func TestCheckPushOptionPolicyNoPolicy(t *testing.T) {
+	protectBranch := &models.ProtectedBranch{}
+
+	err := checkPushOptionPolicy(protectBranch, map[string]string{"anything": "goes"})
+	assert.NoError(t, err)
+}
@@ -0,0 +1,63 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package repo
+
+import (
+	"strings"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/context"
+)
+
+// ProtectBranchCommitPolicyForm is the commit-message policy section of the
+// protected branch settings form.
+type ProtectBranchCommitPolicyForm struct {
+	CommitMessageRegex             string `form:"commit_message_regex"`
+	ForbiddenCommitMessagePatterns string `form:"forbidden_commit_message_patterns"`
+	MaxCommitSubjectLength         int    `form:"max_commit_subject_length"`
+	RequiredCommitTrailers         string `form:"required_commit_trailers"`
+}
+
+// splitLines splits a textarea's newline-separated lines into a trimmed,
+// non-empty slice.
+func splitLines(raw string) []string {
+	var lines []string
+	for _, line := range strings.Split(raw, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// SettingsProtectedBranchCommitPolicyPost saves the commit-message policy
+// section of a protected branch's settings.
+func SettingsProtectedBranchCommitPolicyPost(ctx *context.Context, form ProtectBranchCommitPolicyForm) {
+	repo := ctx.Repo.Repository
+	branchName := ctx.Params(":branchName")
+
+	protectBranch, err := models.GetProtectedBranchBy(repo.ID, branchName)
+	if err != nil {
+		ctx.ServerError("GetProtectedBranchBy", err)
+		return
+	}
+	if protectBranch == nil {
+		protectBranch = &models.ProtectedBranch{RepoID: repo.ID, BranchName: branchName}
+	}
+
+	protectBranch.CommitMessageRegex = strings.TrimSpace(form.CommitMessageRegex)
+	protectBranch.ForbiddenCommitMessagePatterns = splitLines(form.ForbiddenCommitMessagePatterns)
+	protectBranch.MaxCommitSubjectLength = form.MaxCommitSubjectLength
+	protectBranch.RequiredCommitTrailers = splitLines(form.RequiredCommitTrailers)
+
+	if err := models.UpdateProtectedBranch(protectBranch); err != nil {
+		ctx.ServerError("UpdateProtectedBranch", err)
+		return
+	}
+
+	ctx.Flash.Success(ctx.Tr("repo.settings.update_settings_success"))
+	ctx.Redirect(repo.Link() + "/settings/branches/" + branchName)
+}
@@ -0,0 +1,23 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package web
+
+import (
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/routers/web/repo"
+
+	"gitea.com/macaron/binding"
+	"gitea.com/macaron/macaron"
+)
+
+// RegisterProtectedBranchCommitPolicyRoute wires the commit-message policy
+// form of the protected branch settings page at
+// "/:username/:reponame/settings/branches/:branchName/commit-policy".
+func RegisterProtectedBranchCommitPolicyRoute(m *macaron.Macaron) {
+	m.Post("/:username/:reponame/settings/branches/:branchName/commit-policy",
+		context.RepoAssignment(), context.RequireRepoAdmin(),
+		binding.Bind(repo.ProtectBranchCommitPolicyForm{}),
+		repo.SettingsProtectedBranchCommitPolicyPost)
+}